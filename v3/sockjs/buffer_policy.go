@@ -0,0 +1,54 @@
+package sockjs
+
+// sendBufferMode identifies how a SendBufferPolicy behaves once a
+// session's send buffer fills up faster than the attached receiver can
+// drain it.
+type sendBufferMode int
+
+const (
+	// sendBufferUnbounded is the zero value: the buffer grows without limit,
+	// sockjs's original behaviour.
+	sendBufferUnbounded sendBufferMode = iota
+	sendBufferBlock
+	sendBufferDropOldest
+	sendBufferError
+)
+
+// SendBufferPolicy governs how session.sendMessage behaves once messages
+// queue up in the send buffer faster than the attached receiver (or a slow
+// or disconnected client with no receiver attached at all) can drain them.
+// The zero value is BufferUnbounded, matching the package's original
+// behaviour. Construct one with BufferUnbounded, BufferBlock,
+// BufferDropOldest or BufferError and assign it to Options.SendBufferPolicy.
+type SendBufferPolicy struct {
+	mode        sendBufferMode
+	maxBytes    int
+	maxMessages int
+}
+
+// BufferUnbounded lets the send buffer grow without limit. A slow or
+// disconnected client paired with a fast producer can exhaust server
+// memory; prefer one of the bounded policies in that case.
+func BufferUnbounded() SendBufferPolicy {
+	return SendBufferPolicy{mode: sendBufferUnbounded}
+}
+
+// BufferBlock makes Session.Send (and the internal sendMessage it wraps)
+// block once the buffer holds maxBytes worth of undelivered messages,
+// until a receiver attaches and drains the buffer or the session closes.
+func BufferBlock(maxBytes int) SendBufferPolicy {
+	return SendBufferPolicy{mode: sendBufferBlock, maxBytes: maxBytes}
+}
+
+// BufferDropOldest caps the buffer at maxMessages, evicting the oldest
+// undelivered message to make room for each new one instead of growing
+// further.
+func BufferDropOldest(maxMessages int) SendBufferPolicy {
+	return SendBufferPolicy{mode: sendBufferDropOldest, maxMessages: maxMessages}
+}
+
+// BufferError caps the buffer at maxMessages; once full, Session.Send
+// returns ErrSendBufferFull instead of queuing the message.
+func BufferError(maxMessages int) SendBufferPolicy {
+	return SendBufferPolicy{mode: sendBufferError, maxMessages: maxMessages}
+}
@@ -0,0 +1,28 @@
+package sockjs
+
+import "errors"
+
+var (
+	// ErrSessionNotOpen is returned by Session.Send, Session.Recv and the internal
+	// session plumbing once the session has left the "open" state (active or opening).
+	ErrSessionNotOpen = errors.New("sockjs: session not in open state")
+	// errSessionReceiverAttached is returned by session.attachReceiver when another
+	// receiver is already attached to the session.
+	errSessionReceiverAttached = errors.New("sockjs: another receiver still attached")
+	// errInvalidPath is returned when a transport request's URL doesn't contain
+	// a session id segment.
+	errInvalidPath = errors.New("sockjs: invalid request path")
+	// errSessionNotFound is returned by Handler.existingSessionByRequest when no
+	// session is registered under the request's session id.
+	errSessionNotFound = errors.New("sockjs: session not found")
+	// errHandlerShuttingDown is returned by Handler.sessionByRequest once
+	// Shutdown has been called, so transports can refuse new sessions.
+	errHandlerShuttingDown = errors.New("sockjs: handler is shutting down")
+	// ErrSendBufferFull is returned by Session.Send when Options.SendBufferPolicy
+	// is BufferError and the send buffer has reached its configured capacity.
+	ErrSendBufferFull = errors.New("sockjs: send buffer full")
+	// errReceiverInterrupted is the err passed to Observer.OnReceiverDetach
+	// when a receiver goes away via interruptedNotify rather than finishing
+	// cleanly via doneNotify.
+	errReceiverInterrupted = errors.New("sockjs: receiver interrupted")
+)
@@ -0,0 +1,90 @@
+package sockjs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// eventSourcePrelude mirrors xhrStreamingPrelude: 2KB of "\r" padding (blank
+// lines to an SSE parser) followed by "\n\n", so that older browsers and intermediaries start delivering the response
+// immediately instead of buffering it. Skipped on HTTP/2, same rationale as
+// xhrStreaming.
+const eventSourcePrelude = 2048
+
+// eventSource handles the eventsource transport. Framing differs from
+// xhr_streaming (each frame is sent as an SSE "data:" event rather than a
+// bare line) but the HTTP/1.x-vs-HTTP/2 tradeoff is identical, so it shares
+// xhrStreamingReceiver and its ResponseLimit/flow-control behaviour.
+func (h *Handler) eventSource(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("content-type", "text/event-stream; charset=UTF-8")
+
+	sess, err := h.sessionByRequest(req)
+	if err == errHandlerShuttingDown {
+		fmt.Fprintf(rw, "data: c[%d,%q]\r\n\r\n", shutdownCloseStatus, shutdownCloseReason)
+		return
+	}
+	if err != nil {
+		http.Error(rw, "", http.StatusBadRequest)
+		return
+	}
+
+	http2 := req.ProtoMajor == 2
+	var preludeLen uint32
+	if !http2 {
+		prelude := strings.Repeat("\r", eventSourcePrelude) + "\n\n"
+		io.WriteString(rw, prelude)
+		preludeLen = uint32(len(prelude))
+		flush(rw)
+	}
+
+	responseLimit := h.options.ResponseLimit
+	if responseLimit == 0 {
+		responseLimit = defaultResponseLimit
+	}
+
+	recv := newEventSourceReceiver(rw, http2, responseLimit, preludeLen)
+	if err := sess.attachReceiver(recv); err != nil {
+		fmt.Fprint(rw, "data: c[2010,\"Another connection still open\"]\r\n\r\n")
+		return
+	}
+
+	select {
+	case <-recv.doneNotify():
+	case <-req.Context().Done():
+		if http2 {
+			recv.close()
+		} else {
+			sess.close()
+		}
+	}
+}
+
+// newEventSourceReceiver adapts xhrStreamingReceiver's framing to SSE's
+// "data: <frame>\r\n\r\n" wire format by wrapping the ResponseWriter.
+func newEventSourceReceiver(rw http.ResponseWriter, http2 bool, responseLimit, written uint32) *eventSourceReceiver {
+	return &eventSourceReceiver{newXhrStreamingReceiver(&eventSourceWriter{ResponseWriter: rw}, http2, responseLimit, written)}
+}
+
+// eventSourceReceiver is an xhrStreamingReceiver that reports itself as the
+// eventsource transport.
+type eventSourceReceiver struct {
+	*xhrStreamingReceiver
+}
+
+func (r *eventSourceReceiver) receiverType() ReceiverType { return ReceiverTypeEventSource }
+
+// eventSourceWriter rewrites each xhrStreamingReceiver frame write into the
+// SSE data-event wire format.
+type eventSourceWriter struct {
+	http.ResponseWriter
+}
+
+func (w *eventSourceWriter) Write(p []byte) (int, error) {
+	frame := strings.TrimSuffix(string(p), "\n")
+	if _, err := io.WriteString(w.ResponseWriter, "data: "+frame+"\r\n\r\n"); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
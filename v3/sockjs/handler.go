@@ -0,0 +1,133 @@
+package sockjs
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler dispatches SockJS transport requests to sessions, creating sessions
+// on demand and routing subsequent requests for the same session id to the
+// same *session value.
+type Handler struct {
+	mux sync.Mutex
+
+	sessions map[string]*session
+	options  Options
+
+	// handlerFunc is invoked once, in its own goroutine, for every newly
+	// created session. It is where a user's application-level echo/chat/etc.
+	// logic lives, talking to the session via Recv/Send/Close.
+	handlerFunc func(*session)
+
+	// defaultStore is the lazily created SessionStore used when
+	// Options.Store is left nil; see store().
+	defaultStore SessionStore
+
+	// shuttingDown is set by Shutdown; once true, new sessions are refused.
+	shuttingDown bool
+	// onShutdown holds the callbacks registered via RegisterOnShutdown.
+	onShutdown []func()
+}
+
+// store returns the configured SessionStore, falling back to a lazily
+// created in-process default so Handler works standalone, same as before
+// SessionStore existed.
+func (h *Handler) store() SessionStore {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	if h.options.Store != nil {
+		return h.options.Store
+	}
+	if h.defaultStore == nil {
+		h.defaultStore = newLocalSessionStore()
+	}
+	return h.defaultStore
+}
+
+// sessionID extracts the session id path segment from a transport request
+// URL of the form /{prefix}/{server}/{session}/{transport}.
+func (h *Handler) sessionID(req *http.Request) (string, error) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) < 2 {
+		return "", errInvalidPath
+	}
+	return parts[len(parts)-2], nil
+}
+
+// sessionByRequest returns the session named by req's URL, creating it (and
+// spawning handlerFunc for it) if it doesn't already exist.
+func (h *Handler) sessionByRequest(req *http.Request) (*session, error) {
+	id, err := h.sessionID(req)
+	if err != nil {
+		return nil, err
+	}
+	h.mux.Lock()
+	if h.shuttingDown {
+		h.mux.Unlock()
+		return nil, errHandlerShuttingDown
+	}
+	if h.sessions == nil {
+		h.sessions = make(map[string]*session)
+	}
+	sess, existed := h.sessions[id]
+	if !existed {
+		sess = newSession(req, id, h.options.DisconnectDelay, h.options.HeartbeatDelay)
+		sess.sendBufferPolicy = h.options.SendBufferPolicy
+		sess.observer = h.options.Observer
+		h.sessions[id] = sess
+	}
+	h.mux.Unlock()
+
+	if !existed {
+		sess.store = h.store()
+		sess.store.Put(id, SessionMeta{ID: id, Owner: h.options.NodeID, CreatedAt: time.Now()})
+		if sess.observer != nil {
+			sess.observer.OnSessionOpen(id, req)
+		}
+		if h.handlerFunc != nil {
+			go h.handlerFunc(sess)
+		}
+	}
+	return sess, nil
+}
+
+// isShuttingDown reports whether Shutdown has been called.
+func (h *Handler) isShuttingDown() bool {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	return h.shuttingDown
+}
+
+// AcceptForwarded delivers payload, forwarded by another node via the
+// configured SessionStore's pub/sub channel, to the local session id as if
+// it had arrived over xhr_send directly. Reference SessionStore
+// implementations (see sockjs/sessionstore/redis) call this from their
+// subscription loop once they receive a frame for a session they own.
+func (h *Handler) AcceptForwarded(id, payload string) error {
+	h.mux.Lock()
+	sess, ok := h.sessions[id]
+	h.mux.Unlock()
+	if !ok {
+		return errSessionNotFound
+	}
+	return sess.accept(payload)
+}
+
+// existingSessionByRequest returns the session named by req's URL, without
+// creating one. Used by transports like xhr_send that must 404 rather than
+// silently start a new session when the client's session id is unknown.
+func (h *Handler) existingSessionByRequest(req *http.Request) (*session, error) {
+	id, err := h.sessionID(req)
+	if err != nil {
+		return nil, err
+	}
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	sess, ok := h.sessions[id]
+	if !ok {
+		return nil, errSessionNotFound
+	}
+	return sess, nil
+}
@@ -0,0 +1,93 @@
+package sockjs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// validCallback matches the "c" query parameter accepted by the htmlfile
+// transport; anything else is rejected outright since it is interpolated
+// into the response as a JavaScript identifier.
+var validCallback = regexp.MustCompile(`^[a-zA-Z0-9_\.]+$`)
+
+const iframeHTML = `<!doctype html>
+<html><head>
+  <meta http-equiv="X-UA-Compatible" content="IE=edge" />
+  <meta http-equiv="Content-Type" content="text/html; charset=UTF-8" />
+</head><body><h2>Don't panic!</h2>
+  <script>
+    document.domain = document.domain;
+    var c = parent.%s;
+    c.start();
+    function p(d) {c.message(d);};
+    window.onload = function() {c.stop();};
+  </script>
+`
+
+// htmlFileMinSize is the minimum number of bytes the iframe preamble must
+// occupy before the close tag, so that old IE versions start rendering (and
+// so firing onload) before the rest of the streamed content arrives.
+const htmlFileMinSize = 1024
+
+// htmlFile handles the htmlfile transport: an iframe is served that calls
+// back into a callback function supplied by the client for every frame.
+func (h *Handler) htmlFile(rw http.ResponseWriter, req *http.Request) {
+	callback := req.URL.Query().Get("c")
+	if callback == "" {
+		http.Error(rw, "\"callback\" parameter required", http.StatusBadRequest)
+		return
+	}
+	if !validCallback.MatchString(callback) {
+		http.Error(rw, "invalid \"callback\" parameter", http.StatusBadRequest)
+		return
+	}
+
+	rw.Header().Set("content-type", "text/html; charset=UTF-8")
+
+	text := fmt.Sprintf(iframeHTML, callback)
+	padding := htmlFileMinSize - len(text) + len(callback) + 12
+	if padding < 0 {
+		padding = 0
+	}
+	text += strings.Repeat(" ", padding)
+	text += "\r\n\r\n"
+	fmt.Fprint(rw, text)
+	flush(rw)
+
+	sess, err := h.sessionByRequest(req)
+	if err != nil {
+		return
+	}
+	recv := newHtmlFileReceiver(rw)
+	_ = sess.attachReceiver(recv)
+}
+
+// newHtmlFileReceiver adapts oneShotReceiver's framing to htmlfile's
+// "<script>\np(\"...\");\n</script>\r\n" callback format; attachReceiver
+// supplies the open frame itself, so unlike htmlFile's preamble above there
+// is no separate manual write of it here.
+func newHtmlFileReceiver(rw http.ResponseWriter) *oneShotReceiver {
+	return newOneShotReceiver(&htmlFileWriter{ResponseWriter: rw}, ReceiverTypeHtmlFile)
+}
+
+// htmlFileWriter rewrites each oneShotReceiver frame write into the
+// htmlfile callback's wire format: the frame, JSON-encoded as a single
+// JS string literal, passed to the client's callback function.
+type htmlFileWriter struct {
+	http.ResponseWriter
+}
+
+func (w *htmlFileWriter) Write(p []byte) (int, error) {
+	frame := strings.TrimSuffix(string(p), "\n")
+	encoded, err := json.Marshal(frame)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := fmt.Fprintf(w.ResponseWriter, "<script>\np(%s);\n</script>\r\n", encoded); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
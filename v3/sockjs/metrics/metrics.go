@@ -0,0 +1,145 @@
+// Package metrics is a Prometheus adapter for sockjs.Observer, so operators
+// can plug in session/transport metrics without forking the package or
+// writing their own Observer.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ifzz/sockjs-go/v3/sockjs"
+)
+
+// Observer is a sockjs.Observer that records session and transport
+// lifecycle events as Prometheus counter/histogram vectors, labeled by
+// transport (sockjs.ReceiverType's string form) where the event carries one.
+type Observer struct {
+	sessionsOpened   prometheus.Counter
+	sessionsClosed   *prometheus.CounterVec
+	sessionDuration  prometheus.Histogram
+	receiverAttaches *prometheus.CounterVec
+	receiverDetaches *prometheus.CounterVec
+	messagesSent     prometheus.Counter
+	bytesSent        prometheus.Counter
+	messagesReceived prometheus.Counter
+	bytesReceived    prometheus.Counter
+	transportErrors  *prometheus.CounterVec
+}
+
+// New creates an Observer and registers its collectors with reg.
+func New(reg prometheus.Registerer) *Observer {
+	o := &Observer{
+		sessionsOpened: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sockjs_sessions_opened_total",
+			Help: "Total number of SockJS sessions opened.",
+		}),
+		sessionsClosed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sockjs_sessions_closed_total",
+			Help: "Total number of SockJS sessions closed, labeled by close status.",
+		}, []string{"status"}),
+		sessionDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "sockjs_session_duration_seconds",
+			Help:    "Session lifetime from open to close.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		}),
+		receiverAttaches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sockjs_receiver_attaches_total",
+			Help: "Total number of transport receivers attached, labeled by transport.",
+		}, []string{"transport"}),
+		receiverDetaches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sockjs_receiver_detaches_total",
+			Help: "Total number of transport receivers detached, labeled by transport and outcome.",
+		}, []string{"transport", "outcome"}),
+		messagesSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sockjs_messages_sent_total",
+			Help: "Total number of messages sent to clients.",
+		}),
+		bytesSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sockjs_bytes_sent_total",
+			Help: "Total number of message bytes sent to clients.",
+		}),
+		messagesReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sockjs_messages_received_total",
+			Help: "Total number of messages received from clients.",
+		}),
+		bytesReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sockjs_bytes_received_total",
+			Help: "Total number of message bytes received from clients.",
+		}),
+		transportErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sockjs_transport_errors_total",
+			Help: "Total number of transport write errors, labeled by transport.",
+		}, []string{"transport"}),
+	}
+	reg.MustRegister(
+		o.sessionsOpened, o.sessionsClosed, o.sessionDuration,
+		o.receiverAttaches, o.receiverDetaches,
+		o.messagesSent, o.bytesSent, o.messagesReceived, o.bytesReceived,
+		o.transportErrors,
+	)
+	return o
+}
+
+// OnSessionOpen implements sockjs.Observer.
+func (o *Observer) OnSessionOpen(id string, req *http.Request) {
+	o.sessionsOpened.Inc()
+}
+
+// OnSessionClose implements sockjs.Observer.
+func (o *Observer) OnSessionClose(id string, status uint32, reason string, duration time.Duration) {
+	o.sessionsClosed.WithLabelValues(strconv.FormatUint(uint64(status), 10)).Inc()
+	o.sessionDuration.Observe(duration.Seconds())
+}
+
+// OnReceiverAttach implements sockjs.Observer.
+func (o *Observer) OnReceiverAttach(id string, t sockjs.ReceiverType) {
+	o.receiverAttaches.WithLabelValues(transportLabel(t)).Inc()
+}
+
+// OnReceiverDetach implements sockjs.Observer.
+func (o *Observer) OnReceiverDetach(id string, t sockjs.ReceiverType, err error) {
+	outcome := "clean"
+	if err != nil {
+		outcome = "error"
+	}
+	o.receiverDetaches.WithLabelValues(transportLabel(t), outcome).Inc()
+}
+
+// OnMessageSent implements sockjs.Observer.
+func (o *Observer) OnMessageSent(id string, bytes int) {
+	o.messagesSent.Inc()
+	o.bytesSent.Add(float64(bytes))
+}
+
+// OnMessageReceived implements sockjs.Observer.
+func (o *Observer) OnMessageReceived(id string, bytes int) {
+	o.messagesReceived.Inc()
+	o.bytesReceived.Add(float64(bytes))
+}
+
+// OnTransportError implements sockjs.Observer.
+func (o *Observer) OnTransportError(id string, t sockjs.ReceiverType, err error) {
+	o.transportErrors.WithLabelValues(transportLabel(t)).Inc()
+}
+
+func transportLabel(t sockjs.ReceiverType) string {
+	switch t {
+	case sockjs.ReceiverTypeXHR:
+		return "xhr"
+	case sockjs.ReceiverTypeXHRStreaming:
+		return "xhr_streaming"
+	case sockjs.ReceiverTypeEventSource:
+		return "eventsource"
+	case sockjs.ReceiverTypeHtmlFile:
+		return "htmlfile"
+	case sockjs.ReceiverTypeJSONP:
+		return "jsonp"
+	case sockjs.ReceiverTypeWebsocket:
+		return "websocket"
+	default:
+		return "none"
+	}
+}
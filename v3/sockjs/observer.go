@@ -0,0 +1,38 @@
+package sockjs
+
+import (
+	"net/http"
+	"time"
+)
+
+// Observer lets callers observe session and transport lifecycle events for
+// metrics/logging without reaching into Handler or session internals, the
+// way the package's own tests do by touching private state. Every callback
+// runs synchronously on the goroutine that triggered the event, so
+// implementations must not block; hand off to a buffered channel or a
+// metrics client's async path if that matters.
+type Observer interface {
+	// OnSessionOpen is called once, when a session is created by an
+	// incoming request for an id that isn't already known.
+	OnSessionOpen(id string, req *http.Request)
+	// OnSessionClose is called once a session has fully closed, whatever
+	// triggered it (Close, a disconnect timeout, or the underlying
+	// transport going away). status and reason are whatever was passed to
+	// Close, or the zero value if the session closed without one.
+	OnSessionClose(id string, status uint32, reason string, duration time.Duration)
+	// OnReceiverAttach is called each time a transport request successfully
+	// attaches as the session's receiver.
+	OnReceiverAttach(id string, t ReceiverType)
+	// OnReceiverDetach is called each time a receiver is detached, whether
+	// because the transport finished cleanly (err is nil) or failed.
+	OnReceiverDetach(id string, t ReceiverType, err error)
+	// OnMessageSent is called when a message queued via Session.Send has
+	// been accepted by the session, counting its encoded byte length.
+	OnMessageSent(id string, bytes int)
+	// OnMessageReceived is called when a message arriving from the client
+	// (e.g. via xhr_send) has been delivered to a Session.Recv caller.
+	OnMessageReceived(id string, bytes int)
+	// OnTransportError is called when a write to the attached receiver
+	// fails, e.g. because the underlying connection went away.
+	OnTransportError(id string, t ReceiverType, err error)
+}
@@ -0,0 +1,133 @@
+package sockjs
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingObserver is a test double that records every callback it
+// receives, guarded by a mutex since callbacks may arrive from multiple
+// goroutines (heartbeat, detach-watcher, the test itself).
+type recordingObserver struct {
+	mux sync.Mutex
+
+	opened   []string
+	closed   []string
+	attached []ReceiverType
+	detached []ReceiverType
+	sent     []int
+	received []int
+	errors   []ReceiverType
+}
+
+func (o *recordingObserver) OnSessionOpen(id string, req *http.Request) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.opened = append(o.opened, id)
+}
+
+func (o *recordingObserver) OnSessionClose(id string, status uint32, reason string, duration time.Duration) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.closed = append(o.closed, id)
+}
+
+func (o *recordingObserver) OnReceiverAttach(id string, t ReceiverType) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.attached = append(o.attached, t)
+}
+
+func (o *recordingObserver) OnReceiverDetach(id string, t ReceiverType, err error) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.detached = append(o.detached, t)
+}
+
+func (o *recordingObserver) OnMessageSent(id string, bytes int) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.sent = append(o.sent, bytes)
+}
+
+func (o *recordingObserver) OnMessageReceived(id string, bytes int) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.received = append(o.received, bytes)
+}
+
+func (o *recordingObserver) OnTransportError(id string, t ReceiverType, err error) {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.errors = append(o.errors, t)
+}
+
+// xhrStreamingTestReceiver is a testReceiver that reports itself as the
+// xhr_streaming transport, so Observer-facing tests can assert on a
+// realistic ReceiverType instead of testReceiver's default ReceiverTypeNone.
+type xhrStreamingTestReceiver struct {
+	*testReceiver
+}
+
+func (t *xhrStreamingTestReceiver) receiverType() ReceiverType { return ReceiverTypeXHRStreaming }
+
+func TestHandler_ObserverSessionLifecycle(t *testing.T) {
+	obs := &recordingObserver{}
+	h := newTestHandler()
+	h.options.Observer = obs
+
+	req, _ := http.NewRequest("POST", "/server/session/xhr_streaming", nil)
+	sess, err := h.sessionByRequest(req)
+	noError(t, err)
+
+	obs.mux.Lock()
+	if len(obs.opened) != 1 || obs.opened[0] != "session" {
+		t.Errorf("Expected one OnSessionOpen call for 'session', got %v", obs.opened)
+	}
+	obs.mux.Unlock()
+
+	recv := &xhrStreamingTestReceiver{newTestReceiver()}
+	noError(t, sess.attachReceiver(recv))
+	obs.mux.Lock()
+	if len(obs.attached) != 1 || obs.attached[0] != ReceiverTypeXHRStreaming {
+		t.Errorf("Expected one OnReceiverAttach(XHRStreaming) call, got %v", obs.attached)
+	}
+	obs.mux.Unlock()
+
+	noError(t, sess.sendMessage("hello"))
+	obs.mux.Lock()
+	if len(obs.sent) != 1 || obs.sent[0] != len("hello") {
+		t.Errorf("Expected one OnMessageSent(5) call, got %v", obs.sent)
+	}
+	obs.mux.Unlock()
+
+	recvErrCh := make(chan error, 1)
+	go func() {
+		_, err := sess.Recv()
+		recvErrCh <- err
+	}()
+	noError(t, sess.accept("world"))
+	select {
+	case err := <-recvErrCh:
+		noError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Recv did not return the accepted message")
+	}
+	obs.mux.Lock()
+	if len(obs.received) != 1 || obs.received[0] != len("world") {
+		t.Errorf("Expected one OnMessageReceived(5) call, got %v", obs.received)
+	}
+	obs.mux.Unlock()
+
+	sess.closing()
+	obs.mux.Lock()
+	defer obs.mux.Unlock()
+	if len(obs.detached) != 1 || obs.detached[0] != ReceiverTypeXHRStreaming {
+		t.Errorf("Expected one OnReceiverDetach(XHRStreaming) call, got %v", obs.detached)
+	}
+	if len(obs.closed) != 1 || obs.closed[0] != "session" {
+		t.Errorf("Expected one OnSessionClose call for 'session', got %v", obs.closed)
+	}
+}
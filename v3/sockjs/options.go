@@ -0,0 +1,41 @@
+package sockjs
+
+import "time"
+
+// Options holds Handler configuration. The zero value is not generally usable:
+// nothing fills in defaults for HeartbeatDelay or DisconnectDelay, which are
+// used as set.
+type Options struct {
+	// HeartbeatDelay is the interval between heartbeat frames sent to an idle,
+	// connected client.
+	HeartbeatDelay time.Duration
+	// DisconnectDelay is how long a session is kept alive without an attached
+	// receiver before it is considered gone.
+	DisconnectDelay time.Duration
+	// ResponseLimit caps the number of bytes written to a single streaming
+	// response (xhr-streaming, eventsource) before it is closed so the client
+	// can reconnect. Ignored for HTTP/2 streams, which instead rely on the
+	// peer's flow-control window; see Handler.xhrStreaming.
+	ResponseLimit uint32
+
+	// Store, if set, is consulted whenever an xhr_send request's session
+	// isn't held locally, letting a frame arriving at a node that doesn't
+	// own the session still be forwarded to the node that does instead of
+	// 404ing. Other transports create a new local session on a miss. Leave nil to keep Handler's original single-process-only
+	// behaviour.
+	Store SessionStore
+	// NodeID identifies this process to Store as the owner of the sessions
+	// it creates, e.g. a hostname or pod name. Only meaningful when Store is
+	// set; ignored otherwise.
+	NodeID string
+
+	// SendBufferPolicy controls what happens when a session's send buffer
+	// fills up faster than its receiver can drain it. The zero value is
+	// BufferUnbounded, the package's original behaviour.
+	SendBufferPolicy SendBufferPolicy
+
+	// Observer, if set, is notified of session and transport lifecycle
+	// events (open/close, receiver attach/detach, messages, errors) for
+	// metrics and logging. Leave nil to opt out.
+	Observer Observer
+}
@@ -0,0 +1,39 @@
+package sockjs
+
+// ReceiverType identifies the transport currently attached to a session, so that
+// observers and tests can distinguish how a session is being served without
+// reaching into transport-specific state.
+type ReceiverType int
+
+const (
+	ReceiverTypeNone ReceiverType = iota
+	ReceiverTypeXHR
+	ReceiverTypeXHRStreaming
+	ReceiverTypeEventSource
+	ReceiverTypeHtmlFile
+	ReceiverTypeJSONP
+	ReceiverTypeWebsocket
+)
+
+// receiver is implemented by every transport that can be attached to a session
+// via session.attachReceiver. A session only ever has a single receiver attached
+// at a time.
+type receiver interface {
+	// sendBulk sends a batch of messages as a single frame, used when flushing
+	// the session's send buffer.
+	sendBulk(messages ...string) error
+	// sendFrame sends a single already-encoded frame (e.g. an "o" open frame,
+	// an "h" heartbeat or a "c[...]" close frame).
+	sendFrame(frame string) error
+	// close notifies the receiver that the session no longer needs it attached.
+	close()
+	// canSend reports whether the receiver is still able to accept frames.
+	canSend() bool
+	// doneNotify is closed once the receiver has finished serving its request.
+	doneNotify() <-chan struct{}
+	// interruptedNotify is closed if the underlying connection went away before
+	// the receiver could be detached cleanly (e.g. client disconnect).
+	interruptedNotify() <-chan struct{}
+	// receiverType reports which transport this receiver implements.
+	receiverType() ReceiverType
+}
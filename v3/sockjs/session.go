@@ -0,0 +1,399 @@
+package sockjs
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SessionState tracks where a session is in its lifecycle.
+type SessionState int
+
+const (
+	// SessionOpening is the state of a freshly created session that has not
+	// yet had a receiver attached (and so has not sent its "o" open frame).
+	SessionOpening SessionState = iota
+	// SessionActive is the state of a session that has sent its open frame.
+	SessionActive
+	// SessionClosing is the state of a session for which Close has been
+	// called, but receivers attaching afterwards still need to be handed
+	// the close frame.
+	SessionClosing
+	// SessionClosed is the terminal state; the session can no longer send or
+	// receive anything.
+	SessionClosed
+)
+
+// session represents a single SockJS connection, independent of which
+// transport (xhr, xhr-streaming, eventsource, ...) is currently serving it.
+// A session outlives any individual HTTP request: a client may reconnect
+// with a new transport request and resume an existing session by id.
+type session struct {
+	mux sync.Mutex
+
+	id    string
+	req   *http.Request
+	store SessionStore
+
+	state      SessionState
+	recv       receiver
+	recvType   ReceiverType
+	sendBuffer []string
+	closeFrame string
+
+	sendBufferPolicy SendBufferPolicy
+	sendBufferBytes  int
+	bufferCond       *sync.Cond
+
+	messagesCh chan string
+	closeCh    chan struct{}
+
+	disconnectDelay time.Duration
+	heartbeatDelay  time.Duration
+	disconnectTimer *time.Timer
+	heartbeatDone   chan struct{}
+
+	observer    Observer
+	createdAt   time.Time
+	closeStatus uint32
+	closeReason string
+}
+
+func newSession(req *http.Request, sessionID string, disconnectDelay, heartbeatDelay time.Duration) *session {
+	s := &session{
+		id:              sessionID,
+		req:             req,
+		state:           SessionOpening,
+		messagesCh:      make(chan string),
+		closeCh:         make(chan struct{}),
+		disconnectDelay: disconnectDelay,
+		heartbeatDelay:  heartbeatDelay,
+		createdAt:       time.Now(),
+	}
+	s.bufferCond = sync.NewCond(&s.mux)
+	s.disconnectTimer = time.AfterFunc(disconnectDelay, s.closing)
+	return s
+}
+
+// ID returns the session identifier assigned on creation.
+func (s *session) ID() string { return s.id }
+
+// Request returns the HTTP request that created the session.
+func (s *session) Request() *http.Request { return s.req }
+
+// GetSessionState reports the session's current lifecycle state.
+func (s *session) GetSessionState() SessionState {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.state
+}
+
+// ReceiverType reports the transport of the most recently attached receiver.
+func (s *session) ReceiverType() ReceiverType {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.recvType
+}
+
+// sendMessage queues msg to be delivered to the client, flushing it
+// immediately if a receiver is currently attached. Whether and how it
+// pushes back on a full buffer is governed by sendBufferPolicy.
+func (s *session) sendMessage(msg string) error {
+	s.mux.Lock()
+	if s.state == SessionClosing || s.state == SessionClosed {
+		s.mux.Unlock()
+		return ErrSessionNotOpen
+	}
+
+	switch s.sendBufferPolicy.mode {
+	case sendBufferBlock:
+		for s.recv == nil && s.sendBufferPolicy.maxBytes > 0 &&
+			s.sendBufferBytes+len(msg) > s.sendBufferPolicy.maxBytes {
+			s.bufferCond.Wait()
+			if s.state == SessionClosing || s.state == SessionClosed {
+				s.mux.Unlock()
+				return ErrSessionNotOpen
+			}
+		}
+	case sendBufferDropOldest:
+		for s.sendBufferPolicy.maxMessages > 0 && len(s.sendBuffer) >= s.sendBufferPolicy.maxMessages {
+			s.sendBufferBytes -= len(s.sendBuffer[0])
+			s.sendBuffer = s.sendBuffer[1:]
+		}
+	case sendBufferError:
+		if s.sendBufferPolicy.maxMessages > 0 && len(s.sendBuffer) >= s.sendBufferPolicy.maxMessages {
+			s.mux.Unlock()
+			return ErrSendBufferFull
+		}
+	}
+
+	s.sendBuffer = append(s.sendBuffer, msg)
+	s.sendBufferBytes += len(msg)
+	recvType := s.recvType
+	var flushErr error
+	if s.recv != nil {
+		if err := s.recv.sendBulk(s.sendBuffer...); err != nil {
+			flushErr = err
+		} else {
+			s.sendBuffer = nil
+			s.sendBufferBytes = 0
+			s.bufferCond.Broadcast()
+		}
+	}
+	s.mux.Unlock()
+
+	if flushErr != nil {
+		if s.observer != nil {
+			s.observer.OnTransportError(s.id, recvType, flushErr)
+		}
+		return flushErr
+	}
+	if s.observer != nil {
+		s.observer.OnMessageSent(s.id, len(msg))
+	}
+	return nil
+}
+
+// BufferedMessages reports how many messages, and how many bytes, are
+// currently queued in the send buffer waiting for a receiver to drain
+// them. Useful for observability alongside a bounded SendBufferPolicy.
+func (s *session) BufferedMessages() (messages, bytes int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.sendBuffer), s.sendBufferBytes
+}
+
+// Send queues a message for delivery to the client, same as sendMessage,
+// under an exported name.
+func (s *session) Send(msg string) error {
+	return s.sendMessage(msg)
+}
+
+// attachReceiver binds a transport's receiver to the session. Only one
+// receiver may be attached at a time; callers that lose the race get
+// errSessionReceiverAttached back. If the session is already closing, the
+// receiver is handed the close frame and closed without being attached, and
+// nil is returned (or the error from writing the close frame).
+func (s *session) attachReceiver(recv receiver) error {
+	s.mux.Lock()
+	if s.recv != nil {
+		s.mux.Unlock()
+		return errSessionReceiverAttached
+	}
+	if s.state == SessionClosing || s.state == SessionClosed {
+		frame := s.closeFrame
+		s.mux.Unlock()
+		if err := recv.sendFrame(frame); err != nil {
+			return err
+		}
+		recv.close()
+		return nil
+	}
+
+	s.recv = recv
+	s.recvType = recv.receiverType()
+	s.disconnectTimer.Stop()
+	opening := s.state == SessionOpening
+	if opening {
+		s.state = SessionActive
+	}
+	buffered := s.sendBuffer
+	s.sendBuffer = nil
+	s.sendBufferBytes = 0
+	s.bufferCond.Broadcast()
+	recvType := s.recvType
+	s.mux.Unlock()
+
+	if s.observer != nil {
+		s.observer.OnReceiverAttach(s.id, recvType)
+	}
+
+	if opening {
+		if err := recv.sendFrame("o"); err != nil {
+			if s.observer != nil {
+				s.observer.OnTransportError(s.id, recvType, err)
+			}
+			s.detachReceiver(err)
+			return err
+		}
+	}
+	if len(buffered) > 0 {
+		if err := recv.sendBulk(buffered...); err != nil {
+			if s.observer != nil {
+				s.observer.OnTransportError(s.id, recvType, err)
+			}
+			s.detachReceiver(err)
+			return err
+		}
+	}
+
+	s.heartbeatDone = make(chan struct{})
+	go s.heartbeat(recv, s.heartbeatDone)
+	go func() {
+		var err error
+		select {
+		case <-recv.doneNotify():
+		case <-recv.interruptedNotify():
+			err = errReceiverInterrupted
+		}
+		s.detachReceiver(err)
+	}()
+	return nil
+}
+
+// heartbeat periodically sends "h" frames to the attached receiver until it
+// is detached, the session closes, or done is signalled.
+func (s *session) heartbeat(recv receiver, done <-chan struct{}) {
+	ticker := time.NewTicker(s.heartbeatDelay)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !recv.canSend() {
+				return
+			}
+			if err := recv.sendFrame("h"); err != nil {
+				if s.observer != nil {
+					s.observer.OnTransportError(s.id, recv.receiverType(), err)
+				}
+				return
+			}
+		case <-recv.doneNotify():
+			return
+		case <-recv.interruptedNotify():
+			return
+		case <-s.closeCh:
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// detachReceiver clears the currently attached receiver, if any, so a new
+// transport request can attach. It is safe to call multiple times. err
+// records why the receiver went away (nil for a clean finish) and is
+// forwarded to Observer.OnReceiverDetach. The receiver itself is not
+// closed here: its lifecycle (and doneCh/interruptCh) belongs to whatever
+// served it (the transport handler, or a test), not to detach.
+func (s *session) detachReceiver(err error) {
+	s.mux.Lock()
+	if s.recv == nil {
+		s.mux.Unlock()
+		return
+	}
+	s.recv = nil
+	recvType := s.recvType
+	if s.heartbeatDone != nil {
+		close(s.heartbeatDone)
+		s.heartbeatDone = nil
+	}
+	if s.state == SessionActive || s.state == SessionClosing {
+		s.disconnectTimer.Reset(s.disconnectDelay)
+	}
+	s.mux.Unlock()
+
+	if s.observer != nil {
+		s.observer.OnReceiverDetach(s.id, recvType, err)
+	}
+}
+
+// accept delivers a message received from the client (e.g. via xhr_send)
+// to whoever is blocked in Recv.
+func (s *session) accept(message string) error {
+	s.mux.Lock()
+	if s.state == SessionClosing || s.state == SessionClosed {
+		s.mux.Unlock()
+		return ErrSessionNotOpen
+	}
+	s.mux.Unlock()
+	select {
+	case s.messagesCh <- message:
+		if s.observer != nil {
+			s.observer.OnMessageReceived(s.id, len(message))
+		}
+		return nil
+	case <-s.closeCh:
+		return ErrSessionNotOpen
+	}
+}
+
+// Recv blocks until a message sent by the client is available, or the
+// session closes.
+func (s *session) Recv() (string, error) {
+	select {
+	case msg, ok := <-s.messagesCh:
+		if !ok {
+			return "", ErrSessionNotOpen
+		}
+		return msg, nil
+	case <-s.closeCh:
+		return "", ErrSessionNotOpen
+	}
+}
+
+// Close marks the session as closing, delivers the close frame to the
+// attached receiver (if any) and remembers it so that any receiver
+// attaching later gets the same frame.
+func (s *session) Close(status uint32, reason string) error {
+	s.mux.Lock()
+	if s.state == SessionClosing || s.state == SessionClosed {
+		s.mux.Unlock()
+		return ErrSessionNotOpen
+	}
+	frame := closeFrame(status, reason)
+	s.closeFrame = frame
+	s.closeStatus = status
+	s.closeReason = reason
+	s.state = SessionClosing
+	recv := s.recv
+	s.bufferCond.Broadcast()
+	s.mux.Unlock()
+
+	if recv != nil {
+		err := recv.sendFrame(frame)
+		recv.close()
+		return err
+	}
+	return nil
+}
+
+// closing fully closes the session: it stops accepting sends/receives and
+// notifies everything waiting on closeCh. It is idempotent.
+func (s *session) closing() {
+	s.mux.Lock()
+	if s.state == SessionClosed {
+		s.mux.Unlock()
+		return
+	}
+	s.state = SessionClosed
+	s.disconnectTimer.Stop()
+	recv := s.recv
+	recvType := s.recvType
+	s.recv = nil
+	close(s.closeCh)
+	close(s.messagesCh)
+	s.bufferCond.Broadcast()
+	status, reason, duration := s.closeStatus, s.closeReason, time.Since(s.createdAt)
+	s.mux.Unlock()
+
+	if s.observer != nil {
+		if recv != nil {
+			s.observer.OnReceiverDetach(s.id, recvType, nil)
+		}
+		s.observer.OnSessionClose(s.id, status, reason, duration)
+	}
+	if recv != nil {
+		recv.close()
+	}
+	if s.store != nil {
+		s.store.Delete(s.id)
+	}
+}
+
+// close is the external entry point used by transports that detect the
+// underlying connection is gone for good (as opposed to a transient poll
+// disconnect); it fully closes the session.
+func (s *session) close() {
+	s.closing()
+}
@@ -0,0 +1,85 @@
+package sockjs
+
+import (
+	"sync"
+	"time"
+)
+
+// SessionMeta is the piece of session state a SessionStore needs to know
+// about, independent of which process owns the live *session value. It lets
+// a node that doesn't own a session still answer "does this session exist,
+// and if so, where" without holding the session itself.
+type SessionMeta struct {
+	// ID is the session identifier, as used in transport URLs.
+	ID string
+	// Owner identifies the process/node that holds the live session, in
+	// whatever form the store implementation finds useful (hostname, pod
+	// name, connection id, ...).
+	Owner string
+	// CreatedAt records when the session registered itself, for
+	// observability/debugging in the reference implementations.
+	CreatedAt time.Time
+}
+
+// SessionStore lets sessions be discovered and reached across a fleet of
+// SockJS processes behind a load balancer, so that an xhr_send (or any other
+// transport frame) arriving at a node which doesn't hold the session can
+// still be delivered instead of 404ing.
+//
+// Get/Put/Delete track which node owns a session. Forward is the pub/sub
+// hook: a node that gets a frame for a session it doesn't own calls Forward
+// to hand the payload to whichever node does; that node is expected to have
+// subscribed for its own sessions and, on receipt, call session.accept as if
+// the frame had arrived locally.
+type SessionStore interface {
+	// Get returns the metadata for id, or ok=false if no node has
+	// registered that session.
+	Get(id string) (meta SessionMeta, ok bool)
+	// Put registers (or refreshes) id as owned by meta.Owner.
+	Put(id string, meta SessionMeta) error
+	// Delete removes id's registration. Called once a session closes.
+	Delete(id string) error
+	// Forward delivers payload to whichever node owns id. It returns an
+	// error if id isn't registered at all; delivery to the owning node is
+	// best-effort past that point.
+	Forward(id string, payload string) error
+}
+
+// localSessionStore is the default SessionStore: an in-process map with no
+// forwarding ability, matching the package's original single-process
+// behaviour. It's what Handler uses when Options.Store is left nil.
+type localSessionStore struct {
+	mux sync.Mutex
+	ids map[string]SessionMeta
+}
+
+func newLocalSessionStore() *localSessionStore {
+	return &localSessionStore{ids: make(map[string]SessionMeta)}
+}
+
+func (s *localSessionStore) Get(id string) (SessionMeta, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	meta, ok := s.ids[id]
+	return meta, ok
+}
+
+func (s *localSessionStore) Put(id string, meta SessionMeta) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.ids[id] = meta
+	return nil
+}
+
+func (s *localSessionStore) Delete(id string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.ids, id)
+	return nil
+}
+
+// Forward can't reach another node from an in-process store: there is no
+// other node. Callers (xhrSend) treat this the same as "session not found".
+func (s *localSessionStore) Forward(id string, payload string) error {
+	return errSessionNotFound
+}
@@ -0,0 +1,71 @@
+package sockjs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocalSessionStore_PutGetDelete(t *testing.T) {
+	s := newLocalSessionStore()
+	if _, ok := s.Get("a"); ok {
+		t.Errorf("session should not be found before Put")
+	}
+	noError(t, s.Put("a", SessionMeta{ID: "a", Owner: "node1"}))
+	meta, ok := s.Get("a")
+	if !ok || meta.Owner != "node1" {
+		t.Errorf("unexpected meta after Put, got %+v, ok=%v", meta, ok)
+	}
+	noError(t, s.Delete("a"))
+	if _, ok := s.Get("a"); ok {
+		t.Errorf("session should not be found after Delete")
+	}
+}
+
+func TestLocalSessionStore_ForwardUnknownSession(t *testing.T) {
+	s := newLocalSessionStore()
+	if err := s.Forward("missing", "payload"); err == nil {
+		t.Errorf("Forward should error for a session this store has no other node to reach")
+	}
+}
+
+// forwardingStore is a SessionStore test double that can actually forward:
+// localSessionStore, the package's real default, has no other node to
+// forward to and always errors. forwardingStore stands in for a reference
+// pub/sub-backed implementation (see sockjs/sessionstore/redis) by just
+// recording what it was asked to forward.
+type forwardingStore struct {
+	*localSessionStore
+	forwarded []string
+}
+
+func newForwardingStore() *forwardingStore {
+	return &forwardingStore{localSessionStore: newLocalSessionStore()}
+}
+
+func (s *forwardingStore) Forward(id string, payload string) error {
+	if _, ok := s.Get(id); !ok {
+		return errSessionNotFound
+	}
+	s.forwarded = append(s.forwarded, payload)
+	return nil
+}
+
+func TestHandler_XhrSendForwardsToConfiguredStore(t *testing.T) {
+	store := newForwardingStore()
+	noError(t, store.Put("remote-session", SessionMeta{ID: "remote-session", Owner: "node2"}))
+
+	h := newTestHandler()
+	h.options.Store = store
+
+	req, _ := http.NewRequest("POST", "/server/remote-session/xhr_send", strings.NewReader(`["hello"]`))
+	rec := httptest.NewRecorder()
+	h.xhrSend(rec, req)
+	if rec.Code != 204 {
+		t.Errorf("expected forwarded send to report success, got code %d", rec.Code)
+	}
+	if len(store.forwarded) != 1 || store.forwarded[0] != "hello" {
+		t.Errorf("expected \"hello\" to be forwarded, got %v", store.forwarded)
+	}
+}
@@ -58,6 +58,75 @@ func TestSession_ConcurrentSend(t *testing.T) {
 	}
 }
 
+func TestSession_ConcurrentSendBufferDropOldest(t *testing.T) {
+	session := newTestSession()
+	session.sendBufferPolicy = BufferDropOldest(10)
+	done := make(chan bool)
+	for i := 0; i < 100; i++ {
+		go func() {
+			_ = session.sendMessage("message D")
+			done <- true
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		<-done
+	}
+	if messages, _ := session.BufferedMessages(); messages != 10 {
+		t.Errorf("session send buffer should be capped at 10 messages, got %d", messages)
+	}
+}
+
+func TestSession_ConcurrentSendBufferError(t *testing.T) {
+	session := newTestSession()
+	session.sendBufferPolicy = BufferError(10)
+	done := make(chan error)
+	for i := 0; i < 100; i++ {
+		go func() {
+			done <- session.sendMessage("message D")
+		}()
+	}
+	var rejected int
+	for i := 0; i < 100; i++ {
+		if err := <-done; err == ErrSendBufferFull {
+			rejected++
+		} else if err != nil {
+			t.Errorf("unexpected error, got '%s'", err)
+		}
+	}
+	if rejected != 90 {
+		t.Errorf("expected 90 sends to be rejected once the buffer filled, got %d", rejected)
+	}
+	if messages, _ := session.BufferedMessages(); messages != 10 {
+		t.Errorf("session send buffer should be capped at 10 messages, got %d", messages)
+	}
+}
+
+func TestSession_ConcurrentSendBufferBlock(t *testing.T) {
+	session := newTestSession()
+	session.sendBufferPolicy = BufferBlock(81) // room for 9 "message D"s (9 bytes each)
+	done := make(chan bool)
+	for i := 0; i < 100; i++ {
+		go func() {
+			_ = session.sendMessage("message D")
+			done <- true
+		}()
+	}
+
+	// give the blocked senders a chance to queue up
+	runtime.Gosched()
+	time.Sleep(10 * time.Millisecond)
+	if messages, bytes := session.BufferedMessages(); messages != 9 || bytes != 81 {
+		t.Errorf("expected buffer to stop growing at 9 messages / 81 bytes, got %d messages / %d bytes", messages, bytes)
+	}
+
+	recv := newTestReceiver()
+	noError(t, session.attachReceiver(recv))
+
+	for i := 0; i < 100; i++ {
+		<-done
+	}
+}
+
 func TestSession_AttachReceiver(t *testing.T) {
 	session := newTestSession()
 	recv := &testReceiver{}
@@ -67,7 +136,7 @@ func TestSession_AttachReceiver(t *testing.T) {
 	if session.GetSessionState() != SessionActive {
 		t.Errorf("session in wrong state after receiver attached %d, should be %d", session.GetSessionState(), SessionActive)
 	}
-	session.detachReceiver()
+	session.detachReceiver(nil)
 	if err := session.attachReceiver(recv); err != nil {
 		t.Errorf("Should not return error")
 	}
@@ -144,10 +213,10 @@ func TestSession_AttachReceiverAndRefuse(t *testing.T) {
 
 func TestSession_DetachReceiver(t *testing.T) {
 	session := newTestSession()
-	session.detachReceiver()
-	session.detachReceiver() // idempotent operation
+	session.detachReceiver(nil)
+	session.detachReceiver(nil) // idempotent operation
 	_ = session.attachReceiver(newTestReceiver())
-	session.detachReceiver()
+	session.detachReceiver(nil)
 
 }
 
@@ -0,0 +1,105 @@
+// Package redis is a reference sockjs.SessionStore implementation for
+// running a SockJS Handler across multiple processes behind a load
+// balancer. It stores session ownership as Redis keys and forwards
+// xhr_send-style payloads to the owning node over a Redis pub/sub channel.
+//
+// It deliberately depends on a small PubSubClient interface rather than a
+// specific Redis driver, so callers can plug in whichever client they
+// already use (go-redis, redigo, ...) via a thin adapter.
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ifzz/sockjs-go/v3/sockjs"
+)
+
+// PubSubClient is the subset of a Redis client this store needs: simple
+// key/value storage for ownership records, and a pub/sub channel for
+// forwarding payloads to the node that owns a session.
+type PubSubClient interface {
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Get(ctx context.Context, key string) (value string, ok bool, err error)
+	Del(ctx context.Context, key string) error
+	Publish(ctx context.Context, channel, payload string) error
+	// Subscribe delivers every message published on channel to onMessage,
+	// until ctx is done. It should not return until then (or on error).
+	Subscribe(ctx context.Context, channel string, onMessage func(payload string)) error
+}
+
+// Forwarder is implemented by *sockjs.Handler; it's the receiving end of a
+// forwarded frame once the owning node's subscription picks it up.
+type Forwarder interface {
+	AcceptForwarded(id, payload string) error
+}
+
+const keyPrefix = "sockjs:session:"
+const channelPrefix = "sockjs:forward:"
+
+// Store is a Redis-backed sockjs.SessionStore.
+type Store struct {
+	client PubSubClient
+	ttl    time.Duration
+}
+
+// New returns a Store that keeps ownership records alive for ttl, refreshed
+// on every Put. ttl should comfortably exceed the Handler's
+// Options.DisconnectDelay.
+func New(client PubSubClient, ttl time.Duration) *Store {
+	return &Store{client: client, ttl: ttl}
+}
+
+// Listen subscribes to forwarded frames for sessions owned by nodeID and
+// hands them to fwd.AcceptForwarded, blocking until ctx is done. Run it once
+// per node, e.g. in its own goroutine alongside http.Server.Serve.
+func (s *Store) Listen(ctx context.Context, nodeID string, fwd Forwarder) error {
+	return s.client.Subscribe(ctx, channelPrefix+nodeID, func(payload string) {
+		id, frame, ok := splitForwardedPayload(payload)
+		if !ok {
+			return
+		}
+		_ = fwd.AcceptForwarded(id, frame)
+	})
+}
+
+// Get implements sockjs.SessionStore.
+func (s *Store) Get(id string) (sockjs.SessionMeta, bool) {
+	value, ok, err := s.client.Get(context.Background(), keyPrefix+id)
+	if err != nil || !ok {
+		return sockjs.SessionMeta{}, false
+	}
+	return sockjs.SessionMeta{ID: id, Owner: value}, true
+}
+
+// Put implements sockjs.SessionStore.
+func (s *Store) Put(id string, meta sockjs.SessionMeta) error {
+	return s.client.Set(context.Background(), keyPrefix+id, meta.Owner, s.ttl)
+}
+
+// Delete implements sockjs.SessionStore.
+func (s *Store) Delete(id string) error {
+	return s.client.Del(context.Background(), keyPrefix+id)
+}
+
+// Forward implements sockjs.SessionStore: it publishes payload on the
+// owning node's channel. It returns an error if id isn't registered by
+// anyone; delivery past that point is best-effort, matching Redis pub/sub
+// semantics.
+func (s *Store) Forward(id string, payload string) error {
+	meta, ok := s.Get(id)
+	if !ok {
+		return fmt.Errorf("redis sessionstore: session %q not found", id)
+	}
+	return s.client.Publish(context.Background(), channelPrefix+meta.Owner, id+"\x00"+payload)
+}
+
+func splitForwardedPayload(payload string) (id, frame string, ok bool) {
+	for i := 0; i < len(payload); i++ {
+		if payload[i] == 0 {
+			return payload[:i], payload[i+1:], true
+		}
+	}
+	return "", "", false
+}
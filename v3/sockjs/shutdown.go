@@ -0,0 +1,116 @@
+package sockjs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// shutdownCloseStatus and shutdownCloseReason form the close frame every
+// session gets during Shutdown, matching the SockJS client libraries'
+// well-known "going away" code.
+const (
+	shutdownCloseStatus = 2010
+	shutdownCloseReason = "Going away"
+)
+
+// Shutdown stops Handler from accepting new sessions, closes every existing
+// one, and waits for their attached receivers to drain (or for ctx to be
+// done, whichever comes first), mirroring http.Server.Shutdown. Once the
+// wait finishes, any functions registered with RegisterOnShutdown are
+// invoked. Pending Recv() calls are unblocked with ErrSessionNotOpen.
+func (h *Handler) Shutdown(ctx context.Context) error {
+	h.mux.Lock()
+	h.shuttingDown = true
+	sessions := make([]*session, 0, len(h.sessions))
+	for _, s := range h.sessions {
+		sessions = append(sessions, s)
+	}
+	hooks := append([]func(){}, h.onShutdown...)
+	h.mux.Unlock()
+
+	for _, s := range sessions {
+		_ = s.Close(shutdownCloseStatus, shutdownCloseReason)
+		s.closeAttachedReceiver()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(sessions))
+	for _, s := range sessions {
+		s := s
+		go func() {
+			defer wg.Done()
+			s.waitReceiverDetached(ctx)
+			s.closing()
+		}()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+	}
+
+	for _, fn := range hooks {
+		fn()
+	}
+
+	return ctx.Err()
+}
+
+// RegisterOnShutdown registers fn to be called once Shutdown has finished
+// draining sessions, mirroring http.Server.RegisterOnShutdown. Typically
+// used to stop auxiliary goroutines (e.g. a SessionStore's pub/sub
+// listener) alongside the Handler itself.
+func (h *Handler) RegisterOnShutdown(fn func()) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.onShutdown = append(h.onShutdown, fn)
+}
+
+// writeGoingAwayFrame writes the close frame streaming/polling transports
+// send in place of a normal response once the Handler is shutting down.
+func writeGoingAwayFrame(rw http.ResponseWriter) {
+	fmt.Fprintf(rw, "c[%d,%q]\n", shutdownCloseStatus, shutdownCloseReason)
+}
+
+// closeAttachedReceiver force-closes the session's attached receiver, if
+// any, to unblock the transport handler and let it detach. Close already
+// closes the receiver it hands the close frame to; this also covers a
+// receiver still attached when Close returns early because the session was
+// already closing. Unlike detachReceiver, which never closes a receiver it
+// doesn't own, Shutdown is the reason the transport request is being
+// terminated, so it closes the receiver directly.
+func (s *session) closeAttachedReceiver() {
+	s.mux.Lock()
+	recv := s.recv
+	s.mux.Unlock()
+	if recv != nil {
+		recv.close()
+	}
+}
+
+// waitReceiverDetached blocks until the session has no attached receiver,
+// or ctx is done.
+func (s *session) waitReceiverDetached(ctx context.Context) {
+	for {
+		s.mux.Lock()
+		attached := s.recv != nil
+		s.mux.Unlock()
+		if !attached {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
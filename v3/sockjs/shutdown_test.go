@@ -0,0 +1,87 @@
+package sockjs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_ShutdownDrainsXhrStreamingReceiver(t *testing.T) {
+	h := newTestHandler()
+	h.options.ResponseLimit = 1 << 20 // stay open long enough for Shutdown to drain it
+	req, _ := http.NewRequest("POST", "/server/session/xhr_streaming", nil)
+	rw := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		h.xhrStreaming(rw, req)
+		close(done)
+	}()
+
+	// give the receiver a chance to attach before shutting down
+	sess, _ := h.sessionByRequest(req)
+	for sess.ReceiverType() != ReceiverTypeXHRStreaming {
+		runtime.Gosched()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Errorf("Unexpected error from Shutdown, got '%s'", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("xhrStreaming did not return after Shutdown closed its receiver")
+	}
+	if !strings.Contains(rw.Body.String(), "c[2010,\"Going away\"]") {
+		t.Errorf("Expected going-away close frame in body, got '%s'", rw.Body.String())
+	}
+}
+
+func TestHandler_ShutdownUnblocksRecv(t *testing.T) {
+	h := newTestHandler()
+	req, _ := http.NewRequest("POST", "/server/session/xhr_streaming", nil)
+	sess := newSession(req, "session", time.Hour, time.Hour)
+	h.sessions["session"] = sess
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := sess.Recv()
+		errCh <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Errorf("Unexpected error from Shutdown, got '%s'", err)
+	}
+
+	select {
+	case err := <-errCh:
+		if err != ErrSessionNotOpen {
+			t.Errorf("Expected ErrSessionNotOpen, got '%v'", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Recv did not unblock after Shutdown")
+	}
+}
+
+func TestHandler_ShutdownRefusesNewSessions(t *testing.T) {
+	h := newTestHandler()
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Errorf("Unexpected error from Shutdown, got '%s'", err)
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/server/session/xhr_streaming", nil)
+	h.xhrStreaming(rec, req)
+	if rec.Body.String() != "c[2010,\"Going away\"]\n" {
+		t.Errorf("Unexpected body, got '%s'", rec.Body.String())
+	}
+}
@@ -0,0 +1,22 @@
+package sockjs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// closeFrame formats a SockJS close frame, e.g. c[1000,"Normal closure"].
+func closeFrame(status uint32, reason string) string {
+	return fmt.Sprintf("c[%d,%q]", status, reason)
+}
+
+// writePlainText writes status and message as the entire response body,
+// unlike http.Error, which appends a trailing newline; some callers need
+// the body to match their message exactly.
+func writePlainText(rw http.ResponseWriter, status int, message string) {
+	rw.Header().Set("content-type", "text/plain; charset=utf-8")
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(status)
+	io.WriteString(rw, message)
+}
@@ -0,0 +1,170 @@
+package sockjs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// xhrSend handles xhr_send: it decodes a JSON array of message frames from
+// the request body and hands each one to the named session's Recv queue.
+func (h *Handler) xhrSend(rw http.ResponseWriter, req *http.Request) {
+	if req.Body == nil {
+		writePlainText(rw, http.StatusBadRequest, "Payload expected.")
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil || len(body) == 0 {
+		writePlainText(rw, http.StatusBadRequest, "Payload expected.")
+		return
+	}
+
+	var messages []string
+	if err := json.Unmarshal(body, &messages); err != nil {
+		writePlainText(rw, http.StatusBadRequest, "Broken JSON encoding.")
+		return
+	}
+
+	sess, err := h.existingSessionByRequest(req)
+	if err == errSessionNotFound && h.isShuttingDown() {
+		http.Error(rw, "", http.StatusServiceUnavailable)
+		return
+	}
+	if err == errSessionNotFound {
+		// Not held by this node: maybe another one in the fleet owns it.
+		id, _ := h.sessionID(req)
+		store := h.store()
+		for _, m := range messages {
+			if ferr := store.Forward(id, m); ferr != nil {
+				http.Error(rw, "", http.StatusNotFound)
+				return
+			}
+		}
+		rw.Header().Set("content-type", "text/plain; charset=UTF-8")
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		if err == errInvalidPath {
+			http.Error(rw, "", http.StatusBadRequest)
+		} else {
+			http.Error(rw, "", http.StatusNotFound)
+		}
+		return
+	}
+
+	for _, m := range messages {
+		if err := sess.accept(m); err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	rw.Header().Set("content-type", "text/plain; charset=UTF-8")
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// xhrPoll handles xhr: a single short-lived request that delivers whatever
+// is next for the session (the open frame, buffered messages, or a
+// heartbeat) and then completes, so the client can issue another poll.
+func (h *Handler) xhrPoll(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("content-type", "application/javascript; charset=UTF-8")
+
+	sess, err := h.sessionByRequest(req)
+	if err == errHandlerShuttingDown {
+		writeGoingAwayFrame(rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, "", http.StatusBadRequest)
+		return
+	}
+
+	recv := newOneShotReceiver(rw, ReceiverTypeXHR)
+	if err := sess.attachReceiver(recv); err != nil {
+		fmt.Fprint(rw, "c[2010,\"Another connection still open\"]\n")
+		return
+	}
+
+	select {
+	case <-recv.doneNotify():
+	case <-req.Context().Done():
+		sess.close()
+	}
+}
+
+// oneShotReceiver is a receiver that serves a single HTTP response: it
+// writes the first frame it's given (plus a bulk flush of any already
+// buffered messages), flushes, and then marks itself done so the session
+// detaches it, mirroring the request/response nature of polling transports
+// such as xhr and htmlfile.
+type oneShotReceiver struct {
+	mux         sync.Mutex
+	rw          http.ResponseWriter
+	recvType    ReceiverType
+	doneCh      chan struct{}
+	interruptCh chan struct{}
+}
+
+func newOneShotReceiver(rw http.ResponseWriter, recvType ReceiverType) *oneShotReceiver {
+	return &oneShotReceiver{
+		rw:          rw,
+		recvType:    recvType,
+		doneCh:      make(chan struct{}),
+		interruptCh: make(chan struct{}),
+	}
+}
+
+func (r *oneShotReceiver) sendFrame(frame string) error {
+	defer r.close()
+	_, err := fmt.Fprintf(r.rw, "%s\n", frame)
+	flush(r.rw)
+	return err
+}
+
+func (r *oneShotReceiver) sendBulk(messages ...string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	defer r.close()
+	b, err := json.Marshal(messages)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(r.rw, "a%s\n", b)
+	flush(r.rw)
+	return err
+}
+
+func (r *oneShotReceiver) close() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	select {
+	case <-r.doneCh:
+	default:
+		close(r.doneCh)
+	}
+}
+
+func (r *oneShotReceiver) canSend() bool {
+	select {
+	case <-r.doneCh:
+		return false
+	default:
+		return true
+	}
+}
+
+func (r *oneShotReceiver) doneNotify() <-chan struct{}        { return r.doneCh }
+func (r *oneShotReceiver) interruptedNotify() <-chan struct{} { return r.interruptCh }
+func (r *oneShotReceiver) receiverType() ReceiverType         { return r.recvType }
+
+// flush flushes rw if it supports http.Flusher; polling transports rely on
+// this to make sure the frame reaches the client immediately.
+func flush(rw http.ResponseWriter) {
+	if f, ok := rw.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,180 @@
+package sockjs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// xhrStreamingPrelude is the number of "h" padding bytes written ahead of
+// the open frame on HTTP/1.x connections. Older browsers and transparent
+// proxies buffer small responses, so without this a client may never see
+// anything until the response closes; flushing 2KB up front forces them to
+// start delivering bytes immediately. HTTP/2 streams don't have this
+// problem -- each Write is its own flushed DATA frame -- so it is skipped
+// entirely when req.ProtoMajor == 2.
+const xhrStreamingPrelude = 2048
+
+// defaultResponseLimit is the cap applied on HTTP/1.x when
+// Options.ResponseLimit is left at its zero value, so a stream still
+// terminates (forcing a client reconnect) instead of being held open
+// forever. It's deliberately small -- about the size of the prelude itself
+// -- since a real deployment is expected to configure ResponseLimit
+// explicitly for its own traffic.
+const defaultResponseLimit = xhrStreamingPrelude
+
+// xhrStreaming handles xhr_streaming: a single HTTP response carrying
+// newline-delimited frames for as long as the client keeps the connection
+// open. On HTTP/1.x the response is capped at options.ResponseLimit bytes
+// (the classic SockJS behaviour, working around proxies and browsers that
+// never stop buffering a response): once reached, the receiver closes
+// itself and the client is expected to issue a new xhr_streaming request. A
+// zero ResponseLimit falls back to defaultResponseLimit rather than
+// streaming forever. On HTTP/2 there is no such cap -- the stream is kept
+// open for the life of the session, relying on the peer's own flow-control
+// window rather than a byte-count heuristic.
+func (h *Handler) xhrStreaming(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("content-type", "application/javascript; charset=UTF-8")
+
+	sess, err := h.sessionByRequest(req)
+	if err == errHandlerShuttingDown {
+		writeGoingAwayFrame(rw)
+		return
+	}
+	if err != nil {
+		http.Error(rw, "", http.StatusBadRequest)
+		return
+	}
+
+	http2 := req.ProtoMajor == 2
+	var preludeLen uint32
+	if !http2 {
+		prelude := strings.Repeat("h", xhrStreamingPrelude) + "\n"
+		io.WriteString(rw, prelude)
+		preludeLen = uint32(len(prelude))
+		flush(rw)
+	}
+
+	responseLimit := h.options.ResponseLimit
+	if responseLimit == 0 {
+		responseLimit = defaultResponseLimit
+	}
+
+	recv := newXhrStreamingReceiver(rw, http2, responseLimit, preludeLen)
+	if err := sess.attachReceiver(recv); err != nil {
+		fmt.Fprint(rw, "c[2010,\"Another connection still open\"]\n")
+		return
+	}
+
+	select {
+	case <-recv.doneNotify():
+	case <-req.Context().Done():
+		if http2 {
+			// The peer cancelled the stream; there is nothing left to flush,
+			// just release the receiver so a reconnect can attach.
+			recv.close()
+		} else {
+			sess.close()
+		}
+	}
+}
+
+// xhrStreamingReceiver streams frames to an http.ResponseWriter, flushing
+// after every write so frames reach the client as soon as they're queued.
+type xhrStreamingReceiver struct {
+	mux sync.Mutex
+
+	rw    http.ResponseWriter
+	ctrl  *http.ResponseController
+	http2 bool
+
+	responseLimit uint32
+	written       uint32
+
+	doneCh      chan struct{}
+	interruptCh chan struct{}
+}
+
+// newXhrStreamingReceiver builds a receiver for a response that has
+// already had written bytes (e.g. the HTTP/1.x prelude) put on the wire
+// outside the receiver; those count against responseLimit from the start.
+func newXhrStreamingReceiver(rw http.ResponseWriter, http2 bool, responseLimit, written uint32) *xhrStreamingReceiver {
+	return &xhrStreamingReceiver{
+		rw:            rw,
+		ctrl:          http.NewResponseController(rw),
+		http2:         http2,
+		responseLimit: responseLimit,
+		written:       written,
+		doneCh:        make(chan struct{}),
+		interruptCh:   make(chan struct{}),
+	}
+}
+
+func (r *xhrStreamingReceiver) sendFrame(frame string) error {
+	return r.write(frame + "\n")
+}
+
+func (r *xhrStreamingReceiver) sendBulk(messages ...string) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(messages)
+	if err != nil {
+		return err
+	}
+	return r.write("a" + string(b) + "\n")
+}
+
+func (r *xhrStreamingReceiver) write(frame string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	select {
+	case <-r.doneCh:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	n, err := io.WriteString(r.rw, frame)
+	r.written += uint32(n)
+	_ = r.ctrl.Flush()
+	if err != nil {
+		return err
+	}
+	// Flow-control-based streams (HTTP/2) are never byte-capped; classic
+	// HTTP/1.x streams close once ResponseLimit is reached so the client
+	// reconnects.
+	if !r.http2 && r.responseLimit > 0 && r.written >= r.responseLimit {
+		r.closeLocked()
+	}
+	return nil
+}
+
+func (r *xhrStreamingReceiver) close() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.closeLocked()
+}
+
+func (r *xhrStreamingReceiver) closeLocked() {
+	select {
+	case <-r.doneCh:
+	default:
+		close(r.doneCh)
+	}
+}
+
+func (r *xhrStreamingReceiver) canSend() bool {
+	select {
+	case <-r.doneCh:
+		return false
+	default:
+		return true
+	}
+}
+
+func (r *xhrStreamingReceiver) doneNotify() <-chan struct{}        { return r.doneCh }
+func (r *xhrStreamingReceiver) interruptedNotify() <-chan struct{} { return r.interruptCh }
+func (r *xhrStreamingReceiver) receiverType() ReceiverType         { return ReceiverTypeXHRStreaming }
@@ -0,0 +1,79 @@
+package sockjs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// newHTTP2TestServer starts an httptest TLS server configured for HTTP/2,
+// serving handler, with a client that negotiates HTTP/2 against it.
+func newHTTP2TestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(handler)
+	if err := http2.ConfigureServer(srv.Config, &http2.Server{}); err != nil {
+		t.Fatalf("failed to configure HTTP/2 server: %v", err)
+	}
+	srv.TLS = srv.Config.TLSConfig
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	return srv
+}
+
+func TestHandler_XhrStreamingHTTP2(t *testing.T) {
+	h := newTestHandler()
+	srv := newHTTP2TestServer(t, h.xhrStreaming)
+	defer srv.Close()
+
+	client := srv.Client()
+	client.Timeout = 2 * time.Second
+
+	req, _ := http.NewRequest("POST", srv.URL+"/server/session/xhr_streaming", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("test server did not negotiate HTTP/2, got proto %d", resp.ProtoMajor)
+	}
+
+	buf := make([]byte, len(strings.Repeat("h", 2048)))
+	n, err := resp.Body.Read(buf)
+	if err != nil {
+		t.Fatalf("unexpected error reading the open frame: %v", err)
+	}
+	if !strings.HasPrefix(string(buf[:n]), "o") {
+		t.Errorf("HTTP/2 response should omit the 2048-byte prelude and start with the open frame, got leading bytes %q", buf[:n])
+	}
+}
+
+func TestHandler_XhrStreamingHTTP2_NoResponseLimit(t *testing.T) {
+	h := newTestHandler()
+	h.options.ResponseLimit = 1 // would force an immediate close on HTTP/1.x
+	srv := newHTTP2TestServer(t, h.xhrStreaming)
+	defer srv.Close()
+
+	client := srv.Client()
+	client.Timeout = 2 * time.Second
+
+	req, _ := http.NewRequest("POST", srv.URL+"/server/session/xhr_streaming", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	sess, _ := h.sessionByRequest(req)
+	if rt := sess.ReceiverType(); rt != ReceiverTypeXHRStreaming {
+		t.Errorf("unexpected receiver type, got '%v', expected '%v'", rt, ReceiverTypeXHRStreaming)
+	}
+	if sess.GetSessionState() == SessionClosed {
+		t.Errorf("HTTP/2 stream should stay open despite a tiny ResponseLimit")
+	}
+}